@@ -0,0 +1,173 @@
+// Package bbuf implements a "bip buffer": a single-producer/single-consumer
+// circular byte buffer that always hands back a contiguous slice for both
+// writing and reading, wrapping around the end of the backing array by
+// keeping a second region alive until the first has fully drained.
+//
+// The basic lifecycle is Reserve, Commit, Read, Release: a writer Reserves
+// space, fills it in, and Commits it; a reader Reads the oldest committed
+// data and Releases it once it's done, which is what frees the space for
+// future reservations.
+package bbuf
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNotEnoughSpace is returned by Reserve when the buffer doesn't have a
+// contiguous region of the requested size available.
+var ErrNotEnoughSpace = errors.New("bbuf: not enough space")
+
+// Buffer is a fixed-size bip buffer. The zero value is not usable; use New
+// or NewFromStorage.
+//
+// A Buffer is not safe for concurrent use.
+type Buffer struct {
+	storage Storage
+	buf     []byte
+
+	// Region A holds the oldest committed-but-unreleased bytes; Read and
+	// Release always operate on it.
+	ixa, sza int
+	// Region B holds committed bytes that wrapped around the end of buf
+	// before region A had fully drained. It is promoted into region A once
+	// region A empties out.
+	ixb, szb int
+
+	// The pending reservation, if any. reserveSz == 0 means there is no
+	// outstanding reservation.
+	reserveIx, reserveSz int
+	reserveWrap          bool
+}
+
+// New returns a Buffer backed by a size-byte heap allocation.
+func New(size int) *Buffer {
+	return NewFromStorage(NewHeapStorage(size))
+}
+
+// NewFromStorage returns a Buffer backed by s, starting out empty. Use this
+// instead of New to back a Buffer with something other than a plain heap
+// slice, e.g. NewPooled or NewMMapped.
+func NewFromStorage(s Storage) *Buffer {
+	return &Buffer{storage: s, buf: s.Bytes()}
+}
+
+// Close releases the Buffer's backing storage. The Buffer must not be used
+// afterwards.
+func (b *Buffer) Close() error {
+	return b.storage.Release()
+}
+
+// flusher is implemented by Storage backends that can persist a Buffer's
+// region cursors alongside its data, so they can be restored later (see
+// mmapStorage and Recover). It's not part of the exported Storage interface
+// because most backends have nowhere durable to put it.
+type flusher interface {
+	flush(ixa, sza, ixb, szb int) error
+}
+
+// Flush persists the Buffer's region cursors if its storage supports it
+// (currently only storage created by NewMMapped), and is a no-op otherwise.
+// Call it after Commit or Release so that a crash of the process doesn't
+// lose the corresponding state. Flush doesn't msync or fsync the file, so
+// the state isn't on disk until the OS writes the dirty pages back.
+func (b *Buffer) Flush() error {
+	f, ok := b.storage.(flusher)
+	if !ok {
+		return nil
+	}
+	return f.flush(b.ixa, b.sza, b.ixb, b.szb)
+}
+
+// Reserve returns a slice of the next n bytes available for writing. The
+// caller must fill it in and pass it to Commit before calling Reserve again.
+// It returns ErrNotEnoughSpace if the buffer doesn't have a contiguous free
+// region of at least n bytes; a Buffer never allows itself to be completely
+// filled, so at most len(buf)-1 bytes can be in use at once.
+func (b *Buffer) Reserve(n int) ([]byte, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("bbuf: reserve size must be positive, got %d", n)
+	}
+	if b.reserveSz != 0 {
+		return nil, errors.New("bbuf: previous reservation not yet committed")
+	}
+
+	capacity := len(b.buf)
+	if b.szb > 0 {
+		// Already wrapped: region B can only grow into the gap before
+		// region A.
+		free := b.ixa - (b.ixb + b.szb)
+		if n > free-1 {
+			return nil, ErrNotEnoughSpace
+		}
+		ix := b.ixb + b.szb
+		b.reserveIx, b.reserveSz, b.reserveWrap = ix, n, true
+		return b.buf[ix : ix+n], nil
+	}
+
+	if n > capacity-1-b.sza {
+		return nil, ErrNotEnoughSpace
+	}
+	if tailFree := capacity - (b.ixa + b.sza); n <= tailFree {
+		ix := b.ixa + b.sza
+		b.reserveIx, b.reserveSz, b.reserveWrap = ix, n, false
+		return b.buf[ix : ix+n], nil
+	}
+	if n > b.ixa {
+		return nil, ErrNotEnoughSpace
+	}
+	b.reserveIx, b.reserveSz, b.reserveWrap = 0, n, true
+	return b.buf[0:n], nil
+}
+
+// Commit finalizes the pending reservation, making it visible to Read. n
+// must be no greater than the size passed to the preceding Reserve call.
+func (b *Buffer) Commit(n int) error {
+	if b.reserveSz == 0 {
+		return errors.New("bbuf: no pending reservation to commit")
+	}
+	if n < 0 || n > b.reserveSz {
+		return fmt.Errorf("bbuf: commit size %d exceeds reserved size %d", n, b.reserveSz)
+	}
+
+	if b.reserveWrap {
+		if b.szb == 0 {
+			b.ixb = b.reserveIx
+		}
+		b.szb += n
+	} else {
+		if b.sza == 0 {
+			b.ixa = b.reserveIx
+		}
+		b.sza += n
+	}
+	b.reserveIx, b.reserveSz = 0, 0
+	return nil
+}
+
+// Read returns the oldest contiguous run of committed-but-unreleased bytes,
+// or nil if there's nothing to read. The returned slice aliases the
+// Buffer's backing array and is only valid until the corresponding Release.
+func (b *Buffer) Read() []byte {
+	if b.sza == 0 {
+		return nil
+	}
+	return b.buf[b.ixa : b.ixa+b.sza]
+}
+
+// Release marks the first n bytes previously returned by Read as consumed,
+// freeing that space for future Reserve calls. n must be no greater than
+// the size of the slice Read most recently returned.
+func (b *Buffer) Release(n int) error {
+	if n < 0 || n > b.sza {
+		return fmt.Errorf("bbuf: release size %d exceeds available %d", n, b.sza)
+	}
+	b.ixa += n
+	b.sza -= n
+	if b.sza == 0 {
+		// Region A has fully drained; promote region B in its place.
+		b.ixa, b.sza = b.ixb, b.szb
+		b.ixb, b.szb = 0, 0
+	}
+	return nil
+}
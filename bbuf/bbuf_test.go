@@ -78,44 +78,39 @@ func Test_InterleavedReadsAndWrites(t *testing.T) {
 func Test_Wraparound(t *testing.T) {
 	b := bbuf.New(10)
 
-	// Write & release 5 bytes
-	w1, err := b.Reserve(5)
+	// Commit 8 bytes but only release 6 of them, leaving 2 unreleased bytes
+	// ("gg") at the tail of region A.
+	w1, err := b.Reserve(8)
 	if err != nil {
 		t.Fatalf("b.Reserve: %v", err)
 	}
-	copy(w1, []byte("aaaaa"))
-	if err := b.Commit(4); err != nil {
+	copy(w1, []byte("aaaaaagg"))
+	if err := b.Commit(8); err != nil {
 		t.Fatalf("b.Commit: %v", err)
 	}
 	r1 := b.Read()
-	if got, want := r1, []byte("aaaaa"); !bytes.Equal(got, want) {
+	if got, want := r1, []byte("aaaaaagg"); !bytes.Equal(got, want) {
 		t.Fatalf("got %v, want %v", got, want)
 	}
-	if err := b.Release(len(r1)); err != nil {
+	if err := b.Release(6); err != nil {
 		t.Fatalf("b.Release: %v", err)
 	}
 
-	// Now write 4 bytes, twice. That should wrap us around the end of the buffer.
-	w2, err := b.Reserve(4)
-	if err != nil {
-		t.Fatalf("b.Reserve: %v", err)
-	}
-	copy(w2, []byte("bbbb"))
-	if err := b.Commit(4); err != nil {
-		t.Fatalf("b.Commit: %v", err)
-	}
-	w3, err := b.Reserve(4)
+	// Only 2 bytes of tail space remain, so this 3-byte write can't fit
+	// after region A and wraps around to the start of the buffer instead.
+	w2, err := b.Reserve(3)
 	if err != nil {
 		t.Fatalf("b.Reserve: %v", err)
 	}
-	copy(w3, []byte("cccc"))
-	if err := b.Commit(4); err != nil {
+	copy(w2, []byte("bbb"))
+	if err := b.Commit(3); err != nil {
 		t.Fatalf("b.Commit: %v", err)
 	}
 
-	// Because it wrapped around, the reads will necessarily be split.
+	// Because it wrapped around, the reads will necessarily be split: the
+	// leftover "gg" from region A, then "bbb" from region B.
 	r2 := b.Read()
-	if got, want := r2, []byte("bbbb"); !bytes.Equal(got, want) {
+	if got, want := r2, []byte("gg"); !bytes.Equal(got, want) {
 		t.Fatalf("got %v, want %v", got, want)
 	}
 	if err := b.Release(len(r2)); err != nil {
@@ -123,7 +118,7 @@ func Test_Wraparound(t *testing.T) {
 	}
 
 	r3 := b.Read()
-	if got, want := r3, []byte("cccc"); !bytes.Equal(got, want) {
+	if got, want := r3, []byte("bbb"); !bytes.Equal(got, want) {
 		t.Fatalf("got %v, want %v", got, want)
 	}
 	if err := b.Release(len(r3)); err != nil {
@@ -158,6 +153,12 @@ func Test_OutOfSpace_EdgeCases(t *testing.T) {
 		t.Fatalf("b.Reserve: expected err")
 	}
 
+	// Release all but one byte, leaving just enough room for an 8-byte
+	// reservation to wrap around to the start of the buffer.
+	if err := b.Release(8); err != nil {
+		t.Fatalf("b.Release: %v", err)
+	}
+
 	// 8/10 is allowed
 	if _, err := b.Reserve(8); err != nil {
 		t.Fatalf("b.Reserve: %v", err)
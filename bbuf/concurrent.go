@@ -0,0 +1,171 @@
+package bbuf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// Concurrent is a single-producer/single-consumer variant of Buffer that
+// needs no external locking. Reserve and Commit must only be called from
+// the producer goroutine; Read and Release must only be called from the
+// consumer goroutine. The two sides coordinate purely through a pair of
+// atomic cursors: Commit publishes the new write cursor with a release
+// store, and Read observes it with an acquire load (and vice versa for the
+// read cursor), so no mutex is needed on the hot path.
+type Concurrent struct {
+	buf      []byte
+	capacity uint64
+
+	write atomic.Uint64 // total bytes committed so far; owned by the producer
+	read  atomic.Uint64 // total bytes released so far; owned by the consumer
+
+	reserveLen int // producer-owned: length of the pending reservation, 0 if none
+
+	writable chan struct{} // signaled by Release, awaited by ReserveBlocking
+	readable chan struct{} // signaled by Commit, awaited by ReadBlocking
+}
+
+// NewConcurrent returns a Concurrent buffer backed by a size-byte array.
+func NewConcurrent(size int) *Concurrent {
+	return &Concurrent{
+		buf:      make([]byte, size),
+		capacity: uint64(size),
+		writable: make(chan struct{}, 1),
+		readable: make(chan struct{}, 1),
+	}
+}
+
+// notify performs a non-blocking send, coalescing redundant wakeups into a
+// single pending one so producer/consumer never block on a full channel.
+func notify(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// Reserve returns a slice of the next n bytes available for writing. It
+// returns ErrNotEnoughSpace if the buffer doesn't have a contiguous free
+// region of at least n bytes; like Buffer, it never allows itself to be
+// completely filled.
+//
+// Unlike Buffer, Concurrent doesn't reuse the space before the read cursor
+// until the write cursor wraps around to it, so a reservation that doesn't
+// fit in the remaining tail blocks (or fails) even if the buffer has
+// plenty of free space overall. The write cursor never skips the tail, so
+// such a reservation keeps failing until smaller commits fill the tail
+// exactly. Pick a capacity that's a multiple of your typical reservation
+// size so that reservations always fit the tail whole.
+func (c *Concurrent) Reserve(n int) ([]byte, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("bbuf: reserve size must be positive, got %d", n)
+	}
+	if c.reserveLen != 0 {
+		return nil, errors.New("bbuf: previous reservation not yet committed")
+	}
+
+	writePos := c.write.Load()
+	readPos := c.read.Load() // acquire load of the consumer's progress
+	totalFree := c.capacity - 1 - (writePos - readPos)
+	physIdx := writePos % c.capacity
+	avail := c.capacity - physIdx // tail space, since a reservation must be contiguous
+	if totalFree < avail {
+		avail = totalFree
+	}
+	if uint64(n) > avail {
+		return nil, ErrNotEnoughSpace
+	}
+
+	c.reserveLen = n
+	return c.buf[physIdx : physIdx+uint64(n)], nil
+}
+
+// ReserveBlocking is like Reserve, but instead of returning
+// ErrNotEnoughSpace it blocks until the consumer's Release has made room or
+// ctx is done.
+func (c *Concurrent) ReserveBlocking(ctx context.Context, n int) ([]byte, error) {
+	for {
+		buf, err := c.Reserve(n)
+		if err != ErrNotEnoughSpace {
+			return buf, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-c.writable:
+		}
+	}
+}
+
+// Commit finalizes the pending reservation, publishing it to the consumer.
+// n must be no greater than the size passed to the preceding Reserve call.
+func (c *Concurrent) Commit(n int) error {
+	if c.reserveLen == 0 {
+		return errors.New("bbuf: no pending reservation to commit")
+	}
+	if n < 0 || n > c.reserveLen {
+		return fmt.Errorf("bbuf: commit size %d exceeds reserved size %d", n, c.reserveLen)
+	}
+	c.reserveLen = 0
+	c.write.Add(uint64(n)) // release store: publishes the bytes written above
+	notify(c.readable)
+	return nil
+}
+
+// Read returns the oldest contiguous run of committed-but-unreleased
+// bytes, or nil if there's nothing to read. The returned slice aliases the
+// Concurrent's backing array and is only valid until the corresponding
+// Release.
+func (c *Concurrent) Read() []byte {
+	writePos := c.write.Load() // acquire load of the producer's progress
+	readPos := c.read.Load()
+	total := writePos - readPos
+	if total == 0 {
+		return nil
+	}
+	physIdx := readPos % c.capacity
+	avail := c.capacity - physIdx
+	if avail > total {
+		avail = total
+	}
+	return c.buf[physIdx : physIdx+avail]
+}
+
+// ReadBlocking is like Read, but instead of returning nil it blocks until
+// the producer's Commit has made data available or ctx is done.
+func (c *Concurrent) ReadBlocking(ctx context.Context) ([]byte, error) {
+	for {
+		if buf := c.Read(); buf != nil {
+			return buf, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-c.readable:
+		}
+	}
+}
+
+// Release marks the first n bytes previously returned by Read as consumed,
+// freeing that space for future Reserve calls.
+func (c *Concurrent) Release(n int) error {
+	if n < 0 {
+		return fmt.Errorf("bbuf: invalid release size %d", n)
+	}
+	writePos := c.write.Load()
+	readPos := c.read.Load()
+	total := writePos - readPos
+	physIdx := readPos % c.capacity
+	avail := c.capacity - physIdx
+	if avail > total {
+		avail = total
+	}
+	if uint64(n) > avail {
+		return fmt.Errorf("bbuf: release size %d exceeds available %d", n, avail)
+	}
+	c.read.Add(uint64(n)) // release store: frees the bytes read above
+	notify(c.writable)
+	return nil
+}
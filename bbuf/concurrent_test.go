@@ -0,0 +1,126 @@
+package bbuf_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"rpb.dev/bbufsink/bbuf"
+)
+
+func Test_Concurrent_ReadMyWrites(t *testing.T) {
+	c := bbuf.NewConcurrent(10)
+
+	w, err := c.Reserve(4)
+	if err != nil {
+		t.Fatalf("c.Reserve: %v", err)
+	}
+	copy(w, []byte("abcd"))
+	if err := c.Commit(4); err != nil {
+		t.Fatalf("c.Commit: %v", err)
+	}
+
+	r := c.Read()
+	if !bytes.Equal(r, []byte("abcd")) {
+		t.Fatalf("got %v, want %v", r, "abcd")
+	}
+	if err := c.Release(4); err != nil {
+		t.Fatalf("c.Release: %v", err)
+	}
+}
+
+func Test_Concurrent_ReserveBlockingUnblocksOnRelease(t *testing.T) {
+	c := bbuf.NewConcurrent(8)
+	ctx := context.Background()
+
+	if _, err := c.Reserve(4); err != nil {
+		t.Fatalf("c.Reserve: %v", err)
+	}
+	if err := c.Commit(4); err != nil {
+		t.Fatalf("c.Commit: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if _, err := c.ReserveBlocking(ctx, 4); err != nil {
+			t.Errorf("c.ReserveBlocking: %v", err)
+		}
+	}()
+
+	select {
+	case <-done:
+		t.Fatalf("ReserveBlocking returned before space was freed")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	r := c.Read()
+	if err := c.Release(len(r)); err != nil {
+		t.Fatalf("c.Release: %v", err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("ReserveBlocking never unblocked after Release")
+	}
+}
+
+func Test_Concurrent_ReadBlockingRespectsContextCancellation(t *testing.T) {
+	c := bbuf.NewConcurrent(8)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := c.ReadBlocking(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func Test_Concurrent_Stress(t *testing.T) {
+	const n = 1_000_000
+	c := bbuf.NewConcurrent(4096)
+	ctx := context.Background()
+
+	errCh := make(chan error, 1)
+	go func() {
+		for i := uint64(0); i < n; i++ {
+			w, err := c.ReserveBlocking(ctx, 8)
+			if err != nil {
+				errCh <- err
+				return
+			}
+			binary.BigEndian.PutUint64(w, i)
+			if err := c.Commit(8); err != nil {
+				errCh <- err
+				return
+			}
+		}
+		errCh <- nil
+	}()
+
+	var pending []byte
+	for next := uint64(0); next < n; {
+		r, err := c.ReadBlocking(ctx)
+		if err != nil {
+			t.Fatalf("c.ReadBlocking: %v", err)
+		}
+		pending = append(pending, r...)
+		if err := c.Release(len(r)); err != nil {
+			t.Fatalf("c.Release: %v", err)
+		}
+		for len(pending) >= 8 {
+			got := binary.BigEndian.Uint64(pending[:8])
+			if got != next {
+				t.Fatalf("got record %d, want %d", got, next)
+			}
+			pending = pending[8:]
+			next++
+		}
+	}
+
+	if err := <-errCh; err != nil {
+		t.Fatalf("producer: %v", err)
+	}
+}
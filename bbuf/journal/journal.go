@@ -0,0 +1,245 @@
+// Package journal turns a bbuf.Buffer into a framed, checksummed record
+// log, borrowing the write-ahead-log layout from LevelDB: fixed-size
+// blocks carry a sequence of records, each prefixed with a 7-byte header
+// (4-byte CRC-32C, 2-byte length, 1-byte chunk type). Records larger than
+// a block are split across FIRST/MIDDLE/LAST chunks; records that fit in
+// one chunk are FULL.
+package journal
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+
+	"rpb.dev/bbufsink/bbuf"
+)
+
+const (
+	blockSize  = 32 * 1024
+	headerSize = 7 // 4-byte CRC-32C + 2-byte length + 1-byte chunk type
+)
+
+type chunkType byte
+
+const (
+	chunkFull chunkType = 1 + iota
+	chunkFirst
+	chunkMiddle
+	chunkLast
+)
+
+// ErrCorruptRecord is returned when a chunk's CRC-32C doesn't match its
+// payload.
+var ErrCorruptRecord = errors.New("journal: corrupt record")
+
+// ErrPartialRecord is returned when the underlying buffer runs out of
+// committed data partway through a record.
+var ErrPartialRecord = errors.New("journal: partial record")
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+func checksum(typ chunkType, payload []byte) uint32 {
+	crc := crc32.Update(0, castagnoli, payload)
+	return crc32.Update(crc, castagnoli, []byte{byte(typ)})
+}
+
+// Writer splits records into framed, checksummed chunks and writes them
+// into a bbuf.Buffer.
+//
+// A Writer is not safe for concurrent use.
+type Writer struct {
+	buf       *bbuf.Buffer
+	blockFill int
+}
+
+// NewWriter returns a Writer that appends chunks to buf.
+func NewWriter(buf *bbuf.Buffer) *Writer {
+	return &Writer{buf: buf}
+}
+
+// WriteRecord writes p as one or more chunks, rolling to the next block
+// whenever the current one doesn't have room for a chunk header.
+//
+// If WriteRecord returns an error partway through a multi-chunk record
+// (e.g. buf.Reserve returns bbuf.ErrNotEnoughSpace on a later chunk), the
+// chunks already written are left in the stream with no continuation. The
+// Writer must not be reused after such an error: the stream is unresumable,
+// and the orphaned chunks will cause the next ReadRecord call to fail with
+// ErrCorruptRecord rather than silently merging them into a later record.
+func (w *Writer) WriteRecord(p []byte) error {
+	first := true
+	for first || len(p) > 0 {
+		if blockSize-w.blockFill < headerSize {
+			if err := w.pad(); err != nil {
+				return err
+			}
+		}
+
+		avail := blockSize - w.blockFill - headerSize
+		n := len(p)
+		if n > avail {
+			n = avail
+		}
+		var typ chunkType
+		switch {
+		case first && n == len(p):
+			typ = chunkFull
+		case first:
+			typ = chunkFirst
+		case n == len(p):
+			typ = chunkLast
+		default:
+			typ = chunkMiddle
+		}
+		if err := w.writeChunk(typ, p[:n]); err != nil {
+			return err
+		}
+		p = p[n:]
+		first = false
+	}
+	return nil
+}
+
+// pad fills the remainder of the current block with zero bytes so the next
+// chunk starts on a block boundary.
+func (w *Writer) pad() error {
+	n := blockSize - w.blockFill
+	if n == 0 {
+		return nil
+	}
+	dst, err := w.buf.Reserve(n)
+	if err != nil {
+		return err
+	}
+	for i := range dst {
+		dst[i] = 0
+	}
+	if err := w.buf.Commit(n); err != nil {
+		return err
+	}
+	w.blockFill = 0
+	return nil
+}
+
+func (w *Writer) writeChunk(typ chunkType, payload []byte) error {
+	total := headerSize + len(payload)
+	dst, err := w.buf.Reserve(total)
+	if err != nil {
+		return err
+	}
+	binary.LittleEndian.PutUint32(dst[0:4], checksum(typ, payload))
+	binary.LittleEndian.PutUint16(dst[4:6], uint16(len(payload)))
+	dst[6] = byte(typ)
+	copy(dst[headerSize:], payload)
+	if err := w.buf.Commit(total); err != nil {
+		return err
+	}
+	w.blockFill += total
+	if w.blockFill == blockSize {
+		w.blockFill = 0
+	}
+	return nil
+}
+
+// Reader reassembles chunks written by a Writer back into whole records.
+//
+// A Reader is not safe for concurrent use.
+type Reader struct {
+	buf       *bbuf.Buffer
+	blockFill int
+}
+
+// NewReader returns a Reader that consumes chunks from buf.
+func NewReader(buf *bbuf.Buffer) *Reader {
+	return &Reader{buf: buf}
+}
+
+// ReadRecord reassembles and returns the next record. It returns
+// ErrPartialRecord if buf runs out of committed data partway through a
+// record, and ErrCorruptRecord if a chunk's checksum doesn't match its
+// payload or if a chunk's type is out of place (a FULL/FIRST chunk while
+// already reassembling a record, or a MIDDLE/LAST chunk with no record in
+// progress) — which is what a WriteRecord error can leave behind.
+func (r *Reader) ReadRecord() ([]byte, error) {
+	var record []byte
+	inProgress := false
+	for {
+		if blockSize-r.blockFill < headerSize {
+			if err := r.skipPad(); err != nil {
+				return nil, err
+			}
+		}
+
+		hdr, err := r.readN(headerSize)
+		if err != nil {
+			return nil, err
+		}
+		wantCRC := binary.LittleEndian.Uint32(hdr[0:4])
+		length := binary.LittleEndian.Uint16(hdr[4:6])
+		typ := chunkType(hdr[6])
+
+		switch typ {
+		case chunkFull, chunkFirst:
+			if inProgress {
+				return nil, ErrCorruptRecord
+			}
+		case chunkMiddle, chunkLast:
+			if !inProgress {
+				return nil, ErrCorruptRecord
+			}
+		default:
+			return nil, ErrCorruptRecord
+		}
+
+		payload, err := r.readN(int(length))
+		if err != nil {
+			return nil, err
+		}
+		if checksum(typ, payload) != wantCRC {
+			return nil, ErrCorruptRecord
+		}
+		record = append(record, payload...)
+
+		switch typ {
+		case chunkFull, chunkLast:
+			return record, nil
+		case chunkFirst, chunkMiddle:
+			inProgress = true
+			continue
+		}
+	}
+}
+
+func (r *Reader) skipPad() error {
+	n := blockSize - r.blockFill
+	if n == 0 {
+		return nil
+	}
+	_, err := r.readN(n)
+	return err
+}
+
+// readN accumulates exactly n bytes from buf, releasing each slice as it's
+// copied out.
+func (r *Reader) readN(n int) ([]byte, error) {
+	out := make([]byte, 0, n)
+	for len(out) < n {
+		avail := r.buf.Read()
+		if avail == nil {
+			return nil, ErrPartialRecord
+		}
+		take := n - len(out)
+		if take > len(avail) {
+			take = len(avail)
+		}
+		out = append(out, avail[:take]...)
+		if err := r.buf.Release(take); err != nil {
+			return nil, err
+		}
+		r.blockFill += take
+		if r.blockFill == blockSize {
+			r.blockFill = 0
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,148 @@
+package journal_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"testing"
+
+	"rpb.dev/bbufsink/bbuf"
+	"rpb.dev/bbufsink/bbuf/journal"
+)
+
+func Test_Journal_RoundTrip(t *testing.T) {
+	buf := bbuf.New(1024)
+	w := journal.NewWriter(buf)
+	records := [][]byte{[]byte("hello"), []byte(""), []byte("world")}
+	for _, rec := range records {
+		if err := w.WriteRecord(rec); err != nil {
+			t.Fatalf("w.WriteRecord(%q): %v", rec, err)
+		}
+	}
+
+	r := journal.NewReader(buf)
+	for _, want := range records {
+		got, err := r.ReadRecord()
+		if err != nil {
+			t.Fatalf("r.ReadRecord: %v", err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("got %q, want %q", got, want)
+		}
+	}
+}
+
+func Test_Journal_RecordSpansBlocks(t *testing.T) {
+	buf := bbuf.New(128 * 1024)
+	w := journal.NewWriter(buf)
+
+	big := bytes.Repeat([]byte("x"), 70*1024)
+	if err := w.WriteRecord(big); err != nil {
+		t.Fatalf("w.WriteRecord: %v", err)
+	}
+	if err := w.WriteRecord([]byte("tail")); err != nil {
+		t.Fatalf("w.WriteRecord: %v", err)
+	}
+
+	r := journal.NewReader(buf)
+	got, err := r.ReadRecord()
+	if err != nil {
+		t.Fatalf("r.ReadRecord: %v", err)
+	}
+	if !bytes.Equal(got, big) {
+		t.Fatalf("got %d bytes, want %d bytes", len(got), len(big))
+	}
+	got, err = r.ReadRecord()
+	if err != nil {
+		t.Fatalf("r.ReadRecord: %v", err)
+	}
+	if string(got) != "tail" {
+		t.Fatalf("got %q, want %q", got, "tail")
+	}
+}
+
+// writeRawChunk commits a single hand-built chunk directly to buf, bypassing
+// journal.Writer, so tests can exercise malformed input.
+func writeRawChunk(t *testing.T, buf *bbuf.Buffer, typ byte, payload []byte, crc uint32) {
+	t.Helper()
+	dst, err := buf.Reserve(7 + len(payload))
+	if err != nil {
+		t.Fatalf("buf.Reserve: %v", err)
+	}
+	binary.LittleEndian.PutUint32(dst[0:4], crc)
+	binary.LittleEndian.PutUint16(dst[4:6], uint16(len(payload)))
+	dst[6] = typ
+	copy(dst[7:], payload)
+	if err := buf.Commit(len(dst)); err != nil {
+		t.Fatalf("buf.Commit: %v", err)
+	}
+}
+
+func chunkChecksum(typ byte, payload []byte) uint32 {
+	table := crc32.MakeTable(crc32.Castagnoli)
+	crc := crc32.Update(0, table, payload)
+	return crc32.Update(crc, table, []byte{typ})
+}
+
+func Test_Journal_CorruptChecksum(t *testing.T) {
+	const chunkFull = 1
+	buf := bbuf.New(128)
+	payload := []byte("hello")
+	writeRawChunk(t, buf, chunkFull, payload, chunkChecksum(chunkFull, payload)^0xff)
+
+	if _, err := journal.NewReader(buf).ReadRecord(); err != journal.ErrCorruptRecord {
+		t.Fatalf("got %v, want %v", err, journal.ErrCorruptRecord)
+	}
+}
+
+func Test_Journal_PartialRecord(t *testing.T) {
+	const chunkFull = 1
+	buf := bbuf.New(128)
+	// Announce a 5-byte payload in the header, but never write or commit it.
+	dst, err := buf.Reserve(7)
+	if err != nil {
+		t.Fatalf("buf.Reserve: %v", err)
+	}
+	binary.LittleEndian.PutUint32(dst[0:4], 0)
+	binary.LittleEndian.PutUint16(dst[4:6], 5)
+	dst[6] = chunkFull
+	if err := buf.Commit(7); err != nil {
+		t.Fatalf("buf.Commit: %v", err)
+	}
+
+	if _, err := journal.NewReader(buf).ReadRecord(); err != journal.ErrPartialRecord {
+		t.Fatalf("got %v, want %v", err, journal.ErrPartialRecord)
+	}
+}
+
+func Test_Journal_OrphanedFirstChunk(t *testing.T) {
+	const (
+		chunkFull  = 1
+		chunkFirst = 2
+	)
+	buf := bbuf.New(128)
+
+	// Simulate a WriteRecord that wrote a FIRST chunk and then failed
+	// before writing its continuation, followed by an unrelated record
+	// that committed cleanly.
+	first := []byte("ZZZZZZZZZZ")
+	writeRawChunk(t, buf, chunkFirst, first, chunkChecksum(chunkFirst, first))
+	tail := []byte("tail")
+	writeRawChunk(t, buf, chunkFull, tail, chunkChecksum(chunkFull, tail))
+
+	if _, err := journal.NewReader(buf).ReadRecord(); err != journal.ErrCorruptRecord {
+		t.Fatalf("got %v, want %v", err, journal.ErrCorruptRecord)
+	}
+}
+
+func Test_Journal_MiddleChunkWithNoRecordInProgress(t *testing.T) {
+	const chunkMiddle = 3
+	buf := bbuf.New(128)
+
+	payload := []byte("oops")
+	writeRawChunk(t, buf, chunkMiddle, payload, chunkChecksum(chunkMiddle, payload))
+
+	if _, err := journal.NewReader(buf).ReadRecord(); err != journal.ErrCorruptRecord {
+		t.Fatalf("got %v, want %v", err, journal.ErrCorruptRecord)
+	}
+}
@@ -0,0 +1,133 @@
+//go:build unix
+
+package bbuf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// headerPageSize is the size of the header page prepended to the data
+// region of an mmap'd file. It's one that should comfortably cover the
+// filesystem's page size on any unix the mmap syscall targets.
+const headerPageSize = 4096
+
+// headerMagic identifies a file written by NewMMapped, so Recover can
+// reject files that aren't one of ours.
+const headerMagic = 0xb6bf0001
+
+// Header field byte offsets within the header page.
+const (
+	hdrMagic = 0
+	hdrSize  = 8
+	hdrIxa   = 16
+	hdrSza   = 24
+	hdrIxb   = 32
+	hdrSzb   = 40
+)
+
+// mmapStorage is a Storage backed by an mmap'd file. The file holds a
+// fixed-size header page followed by the buffer's data region; the header
+// records the data region's size plus the Buffer's region cursors, so that
+// Recover can restore a Buffer to where a prior process left off.
+type mmapStorage struct {
+	f       *os.File
+	mapping []byte // header page + data region
+	data    []byte // data region only; what Bytes returns
+}
+
+// NewMMapped returns a Buffer backed by a size-byte mmap'd region of the
+// file at path, which is created (or truncated) and sized to fit. The
+// Buffer starts out empty; use Recover to reopen one a prior process left
+// data in.
+func NewMMapped(path string, size int) (*Buffer, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("bbuf: open %s: %w", path, err)
+	}
+	s, err := newMMapStorage(f, size)
+	if err != nil {
+		return nil, err
+	}
+	binary.LittleEndian.PutUint32(s.mapping[hdrMagic:], headerMagic)
+	binary.LittleEndian.PutUint64(s.mapping[hdrSize:], uint64(size))
+	return NewFromStorage(s), nil
+}
+
+// Recover reopens a Buffer previously created with NewMMapped at path,
+// restoring its region cursors (and hence its committed-but-unreleased
+// data) from the header page written by the prior process's last Flush.
+func Recover(path string) (*Buffer, error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("bbuf: open %s: %w", path, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("bbuf: stat %s: %w", path, err)
+	}
+	size := int(info.Size()) - headerPageSize
+	if size <= 0 {
+		f.Close()
+		return nil, fmt.Errorf("bbuf: %s is too small to be a bbuf file", path)
+	}
+
+	s, err := newMMapStorage(f, size)
+	if err != nil {
+		return nil, err
+	}
+	if got := binary.LittleEndian.Uint32(s.mapping[hdrMagic:]); got != headerMagic {
+		s.Release()
+		return nil, fmt.Errorf("bbuf: %s: bad header magic %#x", path, got)
+	}
+	if got := binary.LittleEndian.Uint64(s.mapping[hdrSize:]); got != uint64(size) {
+		s.Release()
+		return nil, fmt.Errorf("bbuf: %s: header size %d doesn't match file", path, got)
+	}
+
+	b := NewFromStorage(s)
+	b.ixa = int(binary.LittleEndian.Uint64(s.mapping[hdrIxa:]))
+	b.sza = int(binary.LittleEndian.Uint64(s.mapping[hdrSza:]))
+	b.ixb = int(binary.LittleEndian.Uint64(s.mapping[hdrIxb:]))
+	b.szb = int(binary.LittleEndian.Uint64(s.mapping[hdrSzb:]))
+	return b, nil
+}
+
+func newMMapStorage(f *os.File, size int) (*mmapStorage, error) {
+	total := headerPageSize + size
+	if err := f.Truncate(int64(total)); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("bbuf: truncate %s: %w", f.Name(), err)
+	}
+	mapping, err := syscall.Mmap(int(f.Fd()), 0, total, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("bbuf: mmap %s: %w", f.Name(), err)
+	}
+	return &mmapStorage{f: f, mapping: mapping, data: mapping[headerPageSize:]}, nil
+}
+
+func (s *mmapStorage) Bytes() []byte { return s.data }
+
+func (s *mmapStorage) Release() error {
+	err := syscall.Munmap(s.mapping)
+	if cerr := s.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// flush persists the Buffer's region cursors into the header page so a
+// later Recover can pick up where this process left off. It relies on the
+// OS to eventually write the dirty pages back to disk; callers that need a
+// stronger durability guarantee should fsync the file themselves.
+func (s *mmapStorage) flush(ixa, sza, ixb, szb int) error {
+	binary.LittleEndian.PutUint64(s.mapping[hdrIxa:], uint64(ixa))
+	binary.LittleEndian.PutUint64(s.mapping[hdrSza:], uint64(sza))
+	binary.LittleEndian.PutUint64(s.mapping[hdrIxb:], uint64(ixb))
+	binary.LittleEndian.PutUint64(s.mapping[hdrSzb:], uint64(szb))
+	return nil
+}
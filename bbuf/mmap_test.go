@@ -0,0 +1,43 @@
+//go:build unix
+
+package bbuf_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"rpb.dev/bbufsink/bbuf"
+)
+
+func Test_MMap_RecoverRestoresCursors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.bbuf")
+
+	b1, err := bbuf.NewMMapped(path, 64)
+	if err != nil {
+		t.Fatalf("bbuf.NewMMapped: %v", err)
+	}
+	w, err := b1.Reserve(5)
+	if err != nil {
+		t.Fatalf("b1.Reserve: %v", err)
+	}
+	copy(w, []byte("hello"))
+	if err := b1.Commit(5); err != nil {
+		t.Fatalf("b1.Commit: %v", err)
+	}
+	if err := b1.Flush(); err != nil {
+		t.Fatalf("b1.Flush: %v", err)
+	}
+	if err := b1.Close(); err != nil {
+		t.Fatalf("b1.Close: %v", err)
+	}
+
+	b2, err := bbuf.Recover(path)
+	if err != nil {
+		t.Fatalf("bbuf.Recover: %v", err)
+	}
+	defer b2.Close()
+	if got, want := b2.Read(), []byte("hello"); !bytes.Equal(got, want) {
+		t.Fatalf("b2.Read: got %v, want %v", got, want)
+	}
+}
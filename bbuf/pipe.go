@@ -0,0 +1,153 @@
+package bbuf
+
+import (
+	"io"
+	"sync"
+)
+
+// pipe holds the state shared between a PipeReader and PipeWriter wrapping
+// the same Buffer.
+type pipe struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+	buf  *Buffer
+
+	werr error // set once the writer has closed; read by Read
+	rerr error // set once the reader has closed; read by Write
+}
+
+// NewPipe wraps b in a PipeReader and PipeWriter pair that implement
+// io.Reader, io.Writer, and io.Closer with the blocking semantics of
+// io.Pipe: Write blocks while the buffer is full, and Read blocks while the
+// buffer is empty. Unlike io.Pipe, the buffer lets a producer batch several
+// writes before the consumer drains them, instead of rendezvousing on every
+// call.
+func NewPipe(b *Buffer) (*PipeReader, *PipeWriter) {
+	p := &pipe{buf: b}
+	p.cond = sync.NewCond(&p.mu)
+	return &PipeReader{p: p}, &PipeWriter{p: p}
+}
+
+// PipeReader is the read half of a pipe created by NewPipe.
+type PipeReader struct {
+	p *pipe
+}
+
+// Read implements io.Reader. It blocks until data has been committed by the
+// writer or the writer has closed.
+func (r *PipeReader) Read(data []byte) (int, error) {
+	p := r.p
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for {
+		if p.rerr != nil {
+			return 0, p.rerr
+		}
+		if avail := p.buf.Read(); avail != nil {
+			n := copy(data, avail)
+			if err := p.buf.Release(n); err != nil {
+				return 0, err
+			}
+			p.cond.Broadcast()
+			return n, nil
+		}
+		if p.werr != nil {
+			return 0, p.werr
+		}
+		p.cond.Wait()
+	}
+}
+
+// Close closes the reader; it is equivalent to CloseWithError(nil).
+func (r *PipeReader) Close() error {
+	return r.CloseWithError(nil)
+}
+
+// CloseWithError closes the reader. Subsequent Write calls on the paired
+// PipeWriter return err, or io.ErrClosedPipe if err is nil, immediately,
+// without waiting for any already-committed data to be drained — once the
+// reader has closed, nothing will ever drain it. It always returns nil.
+func (r *PipeReader) CloseWithError(err error) error {
+	if err == nil {
+		err = io.ErrClosedPipe
+	}
+	p := r.p
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.rerr == nil {
+		p.rerr = err
+	}
+	p.cond.Broadcast()
+	return nil
+}
+
+// PipeWriter is the write half of a pipe created by NewPipe.
+type PipeWriter struct {
+	p *pipe
+}
+
+// Write implements io.Writer. It blocks while the buffer doesn't have room
+// for the next chunk of data, splitting large writes into pieces that fit
+// rather than requiring the whole write to fit at once.
+func (w *PipeWriter) Write(data []byte) (int, error) {
+	p := w.p
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	total := 0
+	for len(data) > 0 {
+		if p.werr != nil {
+			return total, p.werr
+		}
+		if p.rerr != nil {
+			return total, p.rerr
+		}
+
+		want := len(data)
+		dst, err := p.buf.Reserve(want)
+		for err == ErrNotEnoughSpace && want > 1 {
+			want /= 2
+			dst, err = p.buf.Reserve(want)
+		}
+		if err == ErrNotEnoughSpace {
+			p.cond.Wait()
+			continue
+		}
+		if err != nil {
+			return total, err
+		}
+
+		n := copy(dst, data[:want])
+		if err := p.buf.Commit(n); err != nil {
+			return total, err
+		}
+		p.cond.Broadcast()
+
+		total += n
+		data = data[n:]
+	}
+	return total, nil
+}
+
+// Close closes the writer; it is equivalent to CloseWithError(nil).
+func (w *PipeWriter) Close() error {
+	return w.CloseWithError(nil)
+}
+
+// CloseWithError closes the writer. Subsequent Read calls on the paired
+// PipeReader return err, or io.EOF if err is nil, once any already-committed
+// data has been drained. It always returns nil.
+func (w *PipeWriter) CloseWithError(err error) error {
+	if err == nil {
+		err = io.EOF
+	}
+	p := w.p
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.werr == nil {
+		p.werr = err
+	}
+	p.cond.Broadcast()
+	return nil
+}
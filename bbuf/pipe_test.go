@@ -0,0 +1,76 @@
+package bbuf_test
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"rpb.dev/bbufsink/bbuf"
+)
+
+func Test_Pipe_ReadWrite(t *testing.T) {
+	r, w := bbuf.NewPipe(bbuf.New(16))
+
+	go func() {
+		if _, err := w.Write([]byte("hello")); err != nil {
+			t.Errorf("w.Write: %v", err)
+		}
+		if err := w.Close(); err != nil {
+			t.Errorf("w.Close: %v", err)
+		}
+	}()
+
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("io.ReadAll: %v", err)
+	}
+	if string(got) != "hello" {
+		t.Fatalf("got %q, want %q", got, "hello")
+	}
+}
+
+func Test_Pipe_WriteBlocksUntilReaderDrains(t *testing.T) {
+	r, w := bbuf.NewPipe(bbuf.New(8))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if _, err := w.Write([]byte("0123456789")); err != nil {
+			t.Errorf("w.Write: %v", err)
+		}
+	}()
+
+	select {
+	case <-done:
+		t.Fatalf("Write returned before the reader made room for it")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	buf := make([]byte, 10)
+	n, err := io.ReadFull(r, buf)
+	if err != nil {
+		t.Fatalf("io.ReadFull: %v", err)
+	}
+	if got, want := string(buf[:n]), "0123456789"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Write never unblocked after the reader drained the buffer")
+	}
+}
+
+func Test_Pipe_CloseWithError(t *testing.T) {
+	r, w := bbuf.NewPipe(bbuf.New(8))
+
+	boom := io.ErrUnexpectedEOF
+	if err := w.CloseWithError(boom); err != nil {
+		t.Fatalf("w.CloseWithError: %v", err)
+	}
+
+	if _, err := r.Read(make([]byte, 1)); err != boom {
+		t.Fatalf("r.Read: got %v, want %v", err, boom)
+	}
+}
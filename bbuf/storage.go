@@ -0,0 +1,70 @@
+package bbuf
+
+import "sync"
+
+// Storage is the backing memory for a Buffer. It lets a Buffer be built on
+// top of something other than a plain heap allocation, e.g. a sync.Pool (to
+// reuse buffers across short-lived connections) or an mmap'd file (to
+// persist and recover buffer contents across restarts).
+type Storage interface {
+	// Bytes returns the backing array. It is called once, when the Buffer is
+	// constructed, and must always return a slice of the same underlying
+	// array and length.
+	Bytes() []byte
+
+	// Release returns the backing array to wherever it came from. The
+	// Buffer must not be used afterwards.
+	Release() error
+}
+
+// heapStorage is a Storage backed by a plain heap allocation.
+type heapStorage struct {
+	buf []byte
+}
+
+// NewHeapStorage returns a Storage backed by a size-byte heap allocation.
+// This is what New uses internally; call it directly only if you need to
+// go through NewFromStorage explicitly.
+func NewHeapStorage(size int) Storage {
+	return &heapStorage{buf: make([]byte, size)}
+}
+
+func (s *heapStorage) Bytes() []byte  { return s.buf }
+func (s *heapStorage) Release() error { return nil }
+
+// pooledStorage is a Storage backed by a *[]byte drawn from a sync.Pool.
+// Pool elements are pointers to slices, rather than slices themselves, so
+// that putting one back doesn't force an extra heap allocation boxing the
+// slice header.
+type pooledStorage struct {
+	pool *sync.Pool
+	buf  *[]byte
+}
+
+// NewPooled returns a Buffer backed by a size-byte slice drawn from pool.
+// If pool yields nothing, or a slice shorter than size (whether it was Put
+// there or made by pool's New function), a fresh size-byte slice is
+// allocated instead, and the undersized one is discarded rather than
+// returned to the pool.
+//
+// The Buffer's backing slice goes back to pool when Close is called.
+func NewPooled(size int, pool *sync.Pool) *Buffer {
+	var buf *[]byte
+	if v := pool.Get(); v != nil {
+		buf = v.(*[]byte)
+	}
+	if buf == nil || len(*buf) < size {
+		b := make([]byte, size)
+		buf = &b
+	} else {
+		*buf = (*buf)[:size]
+	}
+	return NewFromStorage(&pooledStorage{pool: pool, buf: buf})
+}
+
+func (s *pooledStorage) Bytes() []byte { return *s.buf }
+
+func (s *pooledStorage) Release() error {
+	s.pool.Put(s.buf)
+	return nil
+}
@@ -0,0 +1,57 @@
+package bbuf_test
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"rpb.dev/bbufsink/bbuf"
+)
+
+func Test_NewPooled_ReusesBuffer(t *testing.T) {
+	pool := &sync.Pool{}
+
+	b1 := bbuf.NewPooled(16, pool)
+	w, err := b1.Reserve(4)
+	if err != nil {
+		t.Fatalf("b1.Reserve: %v", err)
+	}
+	copy(w, []byte("abcd"))
+	if err := b1.Commit(4); err != nil {
+		t.Fatalf("b1.Commit: %v", err)
+	}
+	if err := b1.Close(); err != nil {
+		t.Fatalf("b1.Close: %v", err)
+	}
+
+	b2 := bbuf.NewPooled(16, pool)
+	if got := b2.Read(); got != nil {
+		t.Fatalf("b2.Read: got %v, want nil for a fresh buffer", got)
+	}
+	w2, err := b2.Reserve(4)
+	if err != nil {
+		t.Fatalf("b2.Reserve: %v", err)
+	}
+	copy(w2, []byte("wxyz"))
+	if err := b2.Commit(4); err != nil {
+		t.Fatalf("b2.Commit: %v", err)
+	}
+	if got, want := b2.Read(), []byte("wxyz"); !bytes.Equal(got, want) {
+		t.Fatalf("b2.Read: got %v, want %v", got, want)
+	}
+}
+
+func Test_NewPooled_GrowsUndersizedEntry(t *testing.T) {
+	pool := &sync.Pool{}
+	small := make([]byte, 4)
+	pool.Put(&small)
+
+	b := bbuf.NewPooled(64, pool)
+	w, err := b.Reserve(32)
+	if err != nil {
+		t.Fatalf("b.Reserve: %v", err)
+	}
+	if len(w) != 32 {
+		t.Fatalf("got reservation of %d bytes, want 32", len(w))
+	}
+}
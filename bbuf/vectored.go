@@ -0,0 +1,42 @@
+package bbuf
+
+import "net"
+
+// ReadVectored returns up to two contiguous slices covering all
+// committed-but-unreleased bytes: the oldest run, which lies before the
+// point where the buffer wrapped, and, if the buffer has wrapped, the run
+// from its start. Unlike Read, which only ever returns the first of these,
+// ReadVectored lets a caller hand both segments straight to something like
+// net.Buffers.WriteTo with a *net.TCPConn for a zero-copy, single-syscall
+// drain instead of looping Read/Release twice on every wraparound.
+func (b *Buffer) ReadVectored() net.Buffers {
+	var bufs net.Buffers
+	if b.sza > 0 {
+		bufs = append(bufs, b.buf[b.ixa:b.ixa+b.sza])
+	}
+	if b.szb > 0 {
+		bufs = append(bufs, b.buf[b.ixb:b.ixb+b.szb])
+	}
+	return bufs
+}
+
+// ReleaseVectored releases everything returned by the preceding
+// ReadVectored call, i.e. all currently committed-but-unreleased bytes in
+// both segments.
+func (b *Buffer) ReleaseVectored() error {
+	// Capture both sizes up front: releasing all of region A promotes
+	// region B into its place, so b.szb would already be zeroed out by the
+	// time the second Release call below ran.
+	sza, szb := b.sza, b.szb
+	if sza > 0 {
+		if err := b.Release(sza); err != nil {
+			return err
+		}
+	}
+	if szb > 0 {
+		if err := b.Release(szb); err != nil {
+			return err
+		}
+	}
+	return nil
+}
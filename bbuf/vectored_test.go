@@ -0,0 +1,76 @@
+package bbuf_test
+
+import (
+	"bytes"
+	"testing"
+
+	"rpb.dev/bbufsink/bbuf"
+)
+
+func Test_ReadVectored_Wraparound(t *testing.T) {
+	b := bbuf.New(10)
+
+	// Commit 8 bytes but only release 6 of them, leaving 2 unreleased bytes
+	// ("gg") at the tail of region A.
+	w1, err := b.Reserve(8)
+	if err != nil {
+		t.Fatalf("b.Reserve: %v", err)
+	}
+	copy(w1, []byte("aaaaaagg"))
+	if err := b.Commit(8); err != nil {
+		t.Fatalf("b.Commit: %v", err)
+	}
+	if err := b.Release(6); err != nil {
+		t.Fatalf("b.Release: %v", err)
+	}
+
+	// Only 2 bytes of tail space remain, so this 3-byte write can't fit
+	// after region A and wraps around to the start of the buffer instead.
+	w2, err := b.Reserve(3)
+	if err != nil {
+		t.Fatalf("b.Reserve: %v", err)
+	}
+	copy(w2, []byte("bbb"))
+	if err := b.Commit(3); err != nil {
+		t.Fatalf("b.Commit: %v", err)
+	}
+
+	segs := b.ReadVectored()
+	if len(segs) != 2 {
+		t.Fatalf("got %d segments, want 2", len(segs))
+	}
+	if got, want := segs[0], []byte("gg"); !bytes.Equal(got, want) {
+		t.Fatalf("segment 0: got %v, want %v", got, want)
+	}
+	if got, want := segs[1], []byte("bbb"); !bytes.Equal(got, want) {
+		t.Fatalf("segment 1: got %v, want %v", got, want)
+	}
+
+	if err := b.ReleaseVectored(); err != nil {
+		t.Fatalf("b.ReleaseVectored: %v", err)
+	}
+	if got := b.Read(); got != nil {
+		t.Fatalf("b.Read: got %v, want nil after draining", got)
+	}
+}
+
+func Test_ReadVectored_SingleSegment(t *testing.T) {
+	b := bbuf.New(10)
+
+	w, err := b.Reserve(4)
+	if err != nil {
+		t.Fatalf("b.Reserve: %v", err)
+	}
+	copy(w, []byte("abcd"))
+	if err := b.Commit(4); err != nil {
+		t.Fatalf("b.Commit: %v", err)
+	}
+
+	segs := b.ReadVectored()
+	if len(segs) != 1 {
+		t.Fatalf("got %d segments, want 1", len(segs))
+	}
+	if got, want := segs[0], []byte("abcd"); !bytes.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}